@@ -0,0 +1,153 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// minEarlyRefresh is the floor applied to the default early-refresh
+// window, so that very short-lived tokens don't end up refreshing on
+// effectively every call.
+const minEarlyRefresh = 30 * time.Second
+
+// TokenProvider is the minimal interface a caching token source needs
+// from its upstream: something that can fetch a fresh token on demand.
+// *Config satisfies this via its unexported tokenSource, so most
+// callers just use NewCachingTokenSource; TokenProvider exists so other
+// sources (e.g. tokenexchange.Config) can be wrapped the same way.
+type TokenProvider interface {
+	Token(ctx context.Context) (*oauth2.Token, error)
+}
+
+// CachingTokenSourceOptions configures NewCachingTokenSource.
+type CachingTokenSourceOptions struct {
+	// EarlyRefresh is how long before expiry a refresh is triggered.
+	// If zero, it defaults to 10% of the token's lifetime, with a
+	// floor of 30 seconds.
+	EarlyRefresh time.Duration
+
+	// BlockingRefresh, when true, makes Token calls block until a
+	// refresh completes once the early-refresh window is reached. When
+	// false (the default), the cached token is returned immediately
+	// while refreshing in the background, and callers only block once
+	// the cached token is no longer valid per oauth2.Token.Valid, which
+	// counts a token as expired shortly before its Expiry.
+	BlockingRefresh bool
+
+	// OnRefresh, if set, is called after every refresh attempt
+	// (successful or not) with the token being replaced (nil on the
+	// first fetch) and either the new token or the error.
+	OnRefresh func(old, new *oauth2.Token, err error)
+}
+
+type providerFunc func(ctx context.Context) (*oauth2.Token, error)
+
+func (f providerFunc) Token(ctx context.Context) (*oauth2.Token, error) {
+	return f(ctx)
+}
+
+// NewCachingTokenSource wraps conf in an oauth2.TokenSource that
+// coalesces concurrent refreshes with a singleflight.Group and
+// optionally refreshes ahead of expiry in the background, avoiding the
+// latency stampede that oauth2.ReuseTokenSource's refresh-on-expiry
+// behavior causes under high-QPS concurrent use.
+func NewCachingTokenSource(ctx context.Context, conf *Config, opts CachingTokenSourceOptions) oauth2.TokenSource {
+	return newCachingTokenSource(ctx, providerFunc(func(ctx context.Context) (*oauth2.Token, error) {
+		return (&tokenSource{ctx: ctx, conf: conf}).Token()
+	}), opts)
+}
+
+// NewCachingProviderTokenSource is like NewCachingTokenSource but wraps
+// an arbitrary TokenProvider instead of a *Config, for callers with
+// their own token-fetching logic (e.g. tokenexchange.Config).
+func NewCachingProviderTokenSource(ctx context.Context, provider TokenProvider, opts CachingTokenSourceOptions) oauth2.TokenSource {
+	return newCachingTokenSource(ctx, provider, opts)
+}
+
+func newCachingTokenSource(ctx context.Context, provider TokenProvider, opts CachingTokenSourceOptions) oauth2.TokenSource {
+	return &cachingTokenSource{
+		ctx:      ctx,
+		provider: provider,
+		opts:     opts,
+	}
+}
+
+type cachingTokenSource struct {
+	ctx      context.Context
+	provider TokenProvider
+	opts     CachingTokenSourceOptions
+
+	group singleflight.Group
+
+	mu       sync.Mutex
+	cur      *oauth2.Token
+	issuedAt time.Time
+}
+
+func (c *cachingTokenSource) Token() (*oauth2.Token, error) {
+	c.mu.Lock()
+	cur := c.cur
+	staleButUsable := cur != nil && cur.Valid()
+	// A zero Expiry means the token never expires (see oauth2.Token.Valid),
+	// so it never needs an early refresh either.
+	needsRefresh := cur == nil || (!cur.Expiry.IsZero() && time.Until(cur.Expiry) < c.earlyRefreshWindow(cur))
+	c.mu.Unlock()
+
+	if !needsRefresh {
+		return cur, nil
+	}
+
+	if staleButUsable && !c.opts.BlockingRefresh {
+		go c.refresh()
+		return cur, nil
+	}
+
+	return c.refresh()
+}
+
+func (c *cachingTokenSource) earlyRefreshWindow(cur *oauth2.Token) time.Duration {
+	if c.opts.EarlyRefresh > 0 {
+		return c.opts.EarlyRefresh
+	}
+	c.mu.Lock()
+	issuedAt := c.issuedAt
+	c.mu.Unlock()
+	window := cur.Expiry.Sub(issuedAt) / 10
+	if window < minEarlyRefresh {
+		window = minEarlyRefresh
+	}
+	return window
+}
+
+// refresh fetches a new token, coalescing concurrent callers so that N
+// simultaneous refreshes produce exactly one upstream token request.
+func (c *cachingTokenSource) refresh() (*oauth2.Token, error) {
+	v, err, _ := c.group.Do("token", func() (interface{}, error) {
+		tok, err := c.provider.Token(c.ctx)
+
+		c.mu.Lock()
+		old := c.cur
+		if err == nil {
+			c.cur = tok
+			c.issuedAt = time.Now()
+		}
+		c.mu.Unlock()
+
+		if c.opts.OnRefresh != nil {
+			c.opts.OnRefresh(old, tok, err)
+		}
+		return tok, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*oauth2.Token), nil
+}
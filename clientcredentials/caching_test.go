@@ -0,0 +1,198 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type countingProvider struct {
+	mu      sync.Mutex
+	calls   int32
+	fn      func(n int32) (*oauth2.Token, error)
+	release chan struct{}
+}
+
+func (p *countingProvider) Token(ctx context.Context) (*oauth2.Token, error) {
+	n := atomic.AddInt32(&p.calls, 1)
+	if p.release != nil {
+		<-p.release
+	}
+	return p.fn(n)
+}
+
+func TestCachingTokenSourceReturnsCachedTokenBeforeEarlyRefresh(t *testing.T) {
+	p := &countingProvider{fn: func(n int32) (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(time.Hour)}, nil
+	}}
+	src := NewCachingProviderTokenSource(context.Background(), p, CachingTokenSourceOptions{
+		EarlyRefresh: time.Minute,
+	})
+
+	for i := 0; i < 5; i++ {
+		tok, err := src.Token()
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+		if tok.AccessToken != "tok" {
+			t.Fatalf("AccessToken = %q, want tok", tok.AccessToken)
+		}
+	}
+	if got := atomic.LoadInt32(&p.calls); got != 1 {
+		t.Errorf("provider called %d times, want 1", got)
+	}
+}
+
+func TestCachingTokenSourceBlockingRefreshOnExpiry(t *testing.T) {
+	p := &countingProvider{fn: func(n int32) (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(10 * time.Millisecond)}, nil
+	}}
+	src := NewCachingProviderTokenSource(context.Background(), p, CachingTokenSourceOptions{
+		EarlyRefresh:    time.Millisecond,
+		BlockingRefresh: true,
+	})
+
+	if _, err := src.Token(); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := src.Token(); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if got := atomic.LoadInt32(&p.calls); got != 2 {
+		t.Errorf("provider called %d times, want 2", got)
+	}
+}
+
+func TestCachingTokenSourceSingleflightCoalescesConcurrentRefresh(t *testing.T) {
+	release := make(chan struct{})
+	p := &countingProvider{
+		release: release,
+		fn: func(n int32) (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	src := NewCachingProviderTokenSource(context.Background(), p, CachingTokenSourceOptions{
+		BlockingRefresh: true,
+	})
+
+	var wg sync.WaitGroup
+	const n = 10
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = src.Token()
+		}(i)
+	}
+	// Give every goroutine a chance to reach the provider call before
+	// releasing it, so they all land in the same singleflight.Do.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&p.calls); got != 1 {
+		t.Errorf("provider called %d times, want 1 (singleflight should coalesce)", got)
+	}
+}
+
+func TestCachingTokenSourceOnRefreshHook(t *testing.T) {
+	p := &countingProvider{fn: func(n int32) (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(time.Hour)}, nil
+	}}
+	var gotOld, gotNew *oauth2.Token
+	var gotErr error
+	var calls int32
+	src := NewCachingProviderTokenSource(context.Background(), p, CachingTokenSourceOptions{
+		BlockingRefresh: true,
+		OnRefresh: func(old, new *oauth2.Token, err error) {
+			atomic.AddInt32(&calls, 1)
+			gotOld, gotNew, gotErr = old, new, err
+		},
+	})
+	if _, err := src.Token(); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("OnRefresh called %d times, want 1", calls)
+	}
+	if gotOld != nil {
+		t.Errorf("gotOld = %v, want nil on first fetch", gotOld)
+	}
+	if gotNew == nil || gotNew.AccessToken != "tok" {
+		t.Errorf("gotNew = %v, want token tok", gotNew)
+	}
+	if gotErr != nil {
+		t.Errorf("gotErr = %v, want nil", gotErr)
+	}
+}
+
+func TestCachingTokenSourceNeverRefreshesNonExpiringToken(t *testing.T) {
+	p := &countingProvider{fn: func(n int32) (*oauth2.Token, error) {
+		// A zero Expiry means "no expires_in in the response" — legal
+		// per internal.RetrieveToken and treated by oauth2.Token.Valid
+		// as never expiring.
+		return &oauth2.Token{AccessToken: "tok"}, nil
+	}}
+	src := NewCachingProviderTokenSource(context.Background(), p, CachingTokenSourceOptions{})
+
+	for i := 0; i < 5; i++ {
+		tok, err := src.Token()
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+		if tok.AccessToken != "tok" {
+			t.Fatalf("AccessToken = %q, want tok", tok.AccessToken)
+		}
+	}
+	if got := atomic.LoadInt32(&p.calls); got != 1 {
+		t.Errorf("provider called %d times, want 1 (non-expiring token should never trigger a refresh)", got)
+	}
+}
+
+func TestCachingTokenSourceBackgroundRefreshOnEarlyWindow(t *testing.T) {
+	p := &countingProvider{fn: func(n int32) (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(time.Minute)}, nil
+	}}
+	src := NewCachingProviderTokenSource(context.Background(), p, CachingTokenSourceOptions{
+		EarlyRefresh: time.Hour, // always "early" relative to the short expiry above
+	})
+
+	tok, err := src.Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if tok.AccessToken != "tok" {
+		t.Fatalf("AccessToken = %q, want tok", tok.AccessToken)
+	}
+	// Second call should return the still-valid cached token
+	// immediately while kicking off a background refresh.
+	if _, err := src.Token(); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if atomic.LoadInt32(&p.calls) >= 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&p.calls); got < 2 {
+		t.Errorf("provider called %d times, want at least 2 (initial fetch + background refresh)", got)
+	}
+}
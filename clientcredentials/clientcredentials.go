@@ -0,0 +1,134 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package clientcredentials implements the OAuth2.0 "client credentials" token flow,
+// also known as the "two-legged OAuth 2.0".
+//
+// This should be used when the client is acting on its own behalf or when the client
+// is the resource owner. It may also be used when requesting access to protected
+// resources based on an authorization previously arranged with the authorization
+// server.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6749#section-4.4
+package clientcredentials
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+
+	"github.com/polytomic/oauth2/internal"
+)
+
+// Config describes a 2-legged OAuth2 flow, with both the
+// client application information and the server's endpoint URLs.
+type Config struct {
+	// ClientID is the application's ID.
+	ClientID string
+
+	// ClientSecret is the application's secret.
+	ClientSecret string
+
+	// ClientAssertionFn allows the client to generate a fresh client
+	// assertion (e.g. an RFC 7523 JWT bearer assertion) on each token
+	// request, in place of ClientSecret. See NewJWTAssertionSource and
+	// NewHS256AssertionSource for built-in implementations.
+	ClientAssertionFn func(ctx context.Context) (string, error)
+
+	// AllowSecretWithAssertion permits setting both ClientSecret and
+	// ClientAssertionFn at once, sending the secret alongside the
+	// assertion. Most authorization servers reject that combination,
+	// but a few (e.g. Keycloak) require it, so it's opt-in: setting
+	// both without this flag is a validation error.
+	AllowSecretWithAssertion bool
+
+	// TokenURL is the resource server's token endpoint
+	// URL. This is a constant specific to each server.
+	TokenURL string
+
+	// Scopes specifies optional requested permissions.
+	Scopes []string
+
+	// EndpointParams specifies additional parameters for requests to the token endpoint.
+	EndpointParams url.Values
+
+	// AuthStyle optionally specifies how the endpoint wants the
+	// client ID & client secret sent. The zero value means to
+	// authenticate via the request body (AuthStyleInParams).
+	AuthStyle oauth2.AuthStyle
+}
+
+// Token uses client credentials to retrieve a token.
+func (c *Config) Token(ctx context.Context) (*oauth2.Token, error) {
+	return c.TokenSource(ctx).Token()
+}
+
+// Client returns an HTTP client using the provided token.
+// The token will auto-refresh as necessary.
+func (c *Config) Client(ctx context.Context) *http.Client {
+	return oauth2.NewClient(ctx, c.TokenSource(ctx))
+}
+
+// TokenSource returns a TokenSource that returns t until t expires,
+// automatically refreshing it as necessary using the provided context
+// and the client ID and secret.
+//
+// Most users will use Config.Client instead.
+func (c *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
+	source := &tokenSource{
+		ctx:  ctx,
+		conf: c,
+	}
+	return oauth2.ReuseTokenSource(nil, source)
+}
+
+type tokenSource struct {
+	ctx  context.Context
+	conf *Config
+}
+
+// Token refreshes the token by using a new client credentials request.
+// tokens received this way do not include a refresh token.
+func (c *tokenSource) Token() (*oauth2.Token, error) {
+	if err := c.conf.validate(); err != nil {
+		return nil, err
+	}
+
+	v := url.Values{
+		"grant_type": {"client_credentials"},
+	}
+	if len(c.conf.Scopes) > 0 {
+		v.Set("scope", strings.Join(c.conf.Scopes, " "))
+	}
+	for k, p := range c.conf.EndpointParams {
+		if k == "grant_type" {
+			v.Set(k, p[0])
+			continue
+		}
+		if _, ok := v[k]; ok {
+			return nil, fmt.Errorf("oauth2/clientcredentials: cannot overwrite parameter %q", k)
+		}
+		v[k] = p
+	}
+
+	return internal.RetrieveToken(c.ctx, internal.AuthConfig{
+		ClientID:                         c.conf.ClientID,
+		ClientSecret:                     c.conf.ClientSecret,
+		ClientAssertionFn:                c.conf.ClientAssertionFn,
+		IncludeClientSecretWithAssertion: c.conf.AllowSecretWithAssertion,
+		TokenURL:                         c.conf.TokenURL,
+		AuthStyle:                        c.conf.AuthStyle,
+	}, v)
+}
+
+func (c *Config) validate() error {
+	if c.ClientSecret != "" && c.ClientAssertionFn != nil && !c.AllowSecretWithAssertion {
+		return fmt.Errorf("oauth2/clientcredentials: ClientSecret and ClientAssertionFn are both set; set AllowSecretWithAssertion to send both")
+	}
+	return nil
+}
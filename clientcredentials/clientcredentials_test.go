@@ -159,7 +159,9 @@ func TestTokenRefreshRequest(t *testing.T) {
 			t.Errorf("Content-Type = %q; want %q", got, want)
 		}
 		body, _ := ioutil.ReadAll(r.Body)
-		const want = "audience=audience1&grant_type=client_credentials&scope=scope1+scope2"
+		// newConf sets AuthStyleInParams, so client_id/client_secret are
+		// encoded into the body alongside the other params.
+		const want = "audience=audience1&client_id=CLIENT_ID&client_secret=CLIENT_SECRET&grant_type=client_credentials&scope=scope1+scope2"
 		if string(body) != want {
 			t.Errorf("Unexpected refresh token payload.\n got: %s\nwant: %s\n", body, want)
 		}
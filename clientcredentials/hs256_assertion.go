@@ -0,0 +1,26 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// NewHS256AssertionSource returns a ClientAssertionFn that signs a
+// fresh RFC 7523 §2.2 "client_secret_jwt" assertion on every call,
+// using HMAC-SHA256 over the client secret. This targets providers
+// such as Okta and Azure AD that accept client_secret_jwt without
+// requiring a private key.
+func NewHS256AssertionSource(secret []byte, claims AssertionClaims) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims.claims())
+		if claims.KeyID != "" {
+			tok.Header["kid"] = claims.KeyID
+		}
+		return tok.SignedString(secret)
+	}
+}
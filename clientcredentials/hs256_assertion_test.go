@@ -0,0 +1,114 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestHS256AssertionSource(t *testing.T) {
+	secret := []byte("shared-secret")
+	var gotAssertion, gotAssertionType string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+		gotAssertion = r.FormValue("client_assertion")
+		gotAssertionType = r.FormValue("client_assertion_type")
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=90d64460d14870c08c81352a05dedd3465940a7c&token_type=bearer"))
+	}))
+	defer ts.Close()
+
+	conf := &Config{
+		ClientID: "CLIENT_ID",
+		ClientAssertionFn: NewHS256AssertionSource(secret, AssertionClaims{
+			Issuer:   "CLIENT_ID",
+			Subject:  "CLIENT_ID",
+			TokenURL: ts.URL + "/token",
+		}),
+		TokenURL:       ts.URL + "/token",
+		EndpointParams: url.Values{},
+	}
+	tok, err := conf.Token(context.Background())
+	if err != nil {
+		t.Fatalf("conf.Token: %v", err)
+	}
+	if !tok.Valid() {
+		t.Fatalf("token invalid: %#v", tok)
+	}
+
+	const wantType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	if gotAssertionType != wantType {
+		t.Errorf("client_assertion_type = %q, want %q", gotAssertionType, wantType)
+	}
+
+	parsed, err := jwt.Parse(gotAssertion, func(tok *jwt.Token) (interface{}, error) {
+		if tok.Method.Alg() != "HS256" {
+			t.Errorf("alg = %q, want HS256", tok.Method.Alg())
+		}
+		return secret, nil
+	})
+	if err != nil {
+		t.Fatalf("jwt.Parse: %v", err)
+	}
+	claims := parsed.Claims.(jwt.MapClaims)
+	if got, _ := claims["aud"].(string); got != ts.URL+"/token" {
+		t.Errorf("aud = %q, want %q", got, ts.URL+"/token")
+	}
+}
+
+func TestConfigRejectsSecretAndAssertionByDefault(t *testing.T) {
+	conf := &Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		ClientAssertionFn: func(ctx context.Context) (string, error) {
+			return "assertion", nil
+		},
+		TokenURL: "https://example.com/token",
+	}
+	if _, err := conf.Token(context.Background()); err == nil {
+		t.Error("expected an error when both ClientSecret and ClientAssertionFn are set without AllowSecretWithAssertion")
+	}
+}
+
+func TestConfigAllowsSecretAndAssertionWhenOptedIn(t *testing.T) {
+	var gotSecret, gotAssertion string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+		gotSecret = r.FormValue("client_secret")
+		gotAssertion = r.FormValue("client_assertion")
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=tok&token_type=bearer"))
+	}))
+	defer ts.Close()
+
+	conf := &Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		ClientAssertionFn: func(ctx context.Context) (string, error) {
+			return "ASSERTION", nil
+		},
+		AllowSecretWithAssertion: true,
+		TokenURL:                 ts.URL + "/token",
+	}
+	if _, err := conf.Token(context.Background()); err != nil {
+		t.Fatalf("conf.Token: %v", err)
+	}
+	if gotSecret != "CLIENT_SECRET" {
+		t.Errorf("client_secret = %q, want CLIENT_SECRET", gotSecret)
+	}
+	if gotAssertion != "ASSERTION" {
+		t.Errorf("client_assertion = %q, want ASSERTION", gotAssertion)
+	}
+}
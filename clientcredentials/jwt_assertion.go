@@ -0,0 +1,184 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// maxAssertionLifetime is the upper bound on how long a generated
+// client assertion is valid for, per RFC 7523 §3's recommendation that
+// servers reject assertions with excessive lifetimes.
+const maxAssertionLifetime = time.Hour
+
+// defaultAssertionLifetime is used when JWTAssertionSource.Lifetime is
+// unset.
+const defaultAssertionLifetime = 5 * time.Minute
+
+// AssertionClaims holds the RFC 7523 §2.2 claims shared by the
+// private_key_jwt (NewJWTAssertionSource) and client_secret_jwt
+// (NewHS256AssertionSource) assertion helpers.
+type AssertionClaims struct {
+	// KeyID is placed in the JWT header's "kid" claim, if set.
+	KeyID string
+
+	// Issuer is the JWT "iss" claim, usually the OAuth2 client ID.
+	Issuer string
+
+	// Subject is the JWT "sub" claim, usually the OAuth2 client ID.
+	Subject string
+
+	// Audience is the JWT "aud" claim. It defaults to TokenURL.
+	Audience string
+
+	// TokenURL is used as the audience when Audience is empty;
+	// callers typically set it to the same value as the
+	// clientcredentials.Config's TokenURL.
+	TokenURL string
+
+	// Lifetime is how long the generated assertion is valid for,
+	// clamped to at most one hour. Defaults to 5 minutes.
+	Lifetime time.Duration
+
+	// ExtraClaims are merged into the generated JWT, for
+	// provider-specific fields such as Auth0's tenant claims or Azure
+	// AD's "scp".
+	ExtraClaims map[string]any
+}
+
+// JWTAssertionSource configures a RFC 7523 §2.2 "private_key_jwt"
+// client assertion, signed with an RSA or ECDSA private key.
+type JWTAssertionSource struct {
+	AssertionClaims
+
+	// Key is a PEM-encoded RSA or ECDSA private key (PKCS#1, SEC1 or
+	// PKCS#8). Exactly one of Key or Signer must be set.
+	Key []byte
+
+	// Signer signs the assertion directly, for a key that is already
+	// parsed. It must be an *rsa.PrivateKey (RS256) or an
+	// *ecdsa.PrivateKey (ES256); any other crypto.Signer implementation,
+	// such as one backed by an HSM or KMS, is rejected by
+	// NewJWTAssertionSource with an error. Exactly one of Key or Signer
+	// must be set.
+	Signer crypto.Signer
+}
+
+// NewJWTAssertionSource returns a ClientAssertionFn that signs a fresh
+// RFC 7523 §2.2 JWT client assertion on every call, suitable for use as
+// Config.ClientAssertionFn.
+func NewJWTAssertionSource(opts JWTAssertionSource) (func(ctx context.Context) (string, error), error) {
+	signer, method, err := opts.signer()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) (string, error) {
+		tok := jwt.NewWithClaims(method, opts.AssertionClaims.claims())
+		if opts.KeyID != "" {
+			tok.Header["kid"] = opts.KeyID
+		}
+		return tok.SignedString(signer)
+	}, nil
+}
+
+func (o AssertionClaims) claims() jwt.MapClaims {
+	now := time.Now()
+	lifetime := o.Lifetime
+	if lifetime <= 0 {
+		lifetime = defaultAssertionLifetime
+	}
+	if lifetime > maxAssertionLifetime {
+		lifetime = maxAssertionLifetime
+	}
+
+	aud := o.Audience
+	if aud == "" {
+		aud = o.TokenURL
+	}
+
+	jti := make([]byte, 16)
+	// crypto/rand.Read never returns a non-nil error with the default
+	// reader; a failure here means the OS entropy source is broken, in
+	// which case panicking is preferable to signing a predictable jti.
+	if _, err := rand.Read(jti); err != nil {
+		panic(fmt.Sprintf("clientcredentials: reading random jti: %v", err))
+	}
+
+	claims := jwt.MapClaims{
+		"iss": o.Issuer,
+		"sub": o.Subject,
+		"aud": aud,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"exp": now.Add(lifetime).Unix(),
+	}
+	for k, v := range o.ExtraClaims {
+		claims[k] = v
+	}
+	return claims
+}
+
+func (o JWTAssertionSource) signer() (crypto.Signer, jwt.SigningMethod, error) {
+	if o.Signer != nil && len(o.Key) != 0 {
+		return nil, nil, errors.New("clientcredentials: only one of Key or Signer may be set")
+	}
+	signer := o.Signer
+	if signer == nil {
+		if len(o.Key) == 0 {
+			return nil, nil, errors.New("clientcredentials: Key or Signer is required")
+		}
+		parsed, err := parsePrivateKey(o.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer = parsed
+	}
+	switch signer.(type) {
+	case *rsa.PrivateKey:
+		return signer, jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		return signer, jwt.SigningMethodES256, nil
+	default:
+		return nil, nil, fmt.Errorf("clientcredentials: unsupported signer type %T", signer)
+	}
+}
+
+func parsePrivateKey(pemBytes []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("clientcredentials: no PEM data found in key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("clientcredentials: parsing private key: %w", err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("clientcredentials: unsupported private key type %T", key)
+	}
+}
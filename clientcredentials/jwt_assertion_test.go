@@ -0,0 +1,183 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func pemEncodePKCS8(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestJWTAssertionSource(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		key       any
+		wantAlg   string
+		publicKey any
+	}{
+		{"RSA", rsaKey, "RS256", &rsaKey.PublicKey},
+		{"ECDSA", ecKey, "ES256", &ecKey.PublicKey},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotAssertion, gotAssertionType string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("ParseForm: %v", err)
+				}
+				gotAssertion = r.FormValue("client_assertion")
+				gotAssertionType = r.FormValue("client_assertion_type")
+				w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+				w.Write([]byte("access_token=90d64460d14870c08c81352a05dedd3465940a7c&token_type=bearer"))
+			}))
+			defer ts.Close()
+
+			assertionFn, err := NewJWTAssertionSource(JWTAssertionSource{
+				Key: pemEncodePKCS8(t, tc.key),
+				AssertionClaims: AssertionClaims{
+					KeyID:    "key-1",
+					Issuer:   "CLIENT_ID",
+					Subject:  "CLIENT_ID",
+					TokenURL: ts.URL + "/token",
+					Lifetime: time.Minute,
+					ExtraClaims: map[string]any{
+						"scp": "read:things",
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("NewJWTAssertionSource: %v", err)
+			}
+
+			conf := &Config{
+				ClientID:          "CLIENT_ID",
+				ClientAssertionFn: assertionFn,
+				TokenURL:          ts.URL + "/token",
+				EndpointParams:    url.Values{},
+			}
+			tok, err := conf.Token(context.Background())
+			if err != nil {
+				t.Fatalf("conf.Token: %v", err)
+			}
+			if !tok.Valid() {
+				t.Fatalf("token invalid: %#v", tok)
+			}
+
+			const wantType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+			if gotAssertionType != wantType {
+				t.Errorf("client_assertion_type = %q, want %q", gotAssertionType, wantType)
+			}
+			if gotAssertion == "" {
+				t.Fatal("client_assertion was not sent")
+			}
+
+			parsed, err := jwt.Parse(gotAssertion, func(tok *jwt.Token) (interface{}, error) {
+				if tok.Method.Alg() != tc.wantAlg {
+					t.Errorf("alg = %q, want %q", tok.Method.Alg(), tc.wantAlg)
+				}
+				if kid, _ := tok.Header["kid"].(string); kid != "key-1" {
+					t.Errorf("kid = %q, want %q", kid, "key-1")
+				}
+				return tc.publicKey, nil
+			})
+			if err != nil {
+				t.Fatalf("jwt.Parse: %v", err)
+			}
+			claims, ok := parsed.Claims.(jwt.MapClaims)
+			if !ok {
+				t.Fatalf("unexpected claims type %T", parsed.Claims)
+			}
+			if got, _ := claims["iss"].(string); got != "CLIENT_ID" {
+				t.Errorf("iss = %q, want CLIENT_ID", got)
+			}
+			if got, _ := claims["sub"].(string); got != "CLIENT_ID" {
+				t.Errorf("sub = %q, want CLIENT_ID", got)
+			}
+			if got, _ := claims["aud"].(string); got != ts.URL+"/token" {
+				t.Errorf("aud = %q, want %q", got, ts.URL+"/token")
+			}
+			if got, _ := claims["scp"].(string); got != "read:things" {
+				t.Errorf("scp = %q, want read:things", got)
+			}
+			if jti, _ := claims["jti"].(string); jti == "" {
+				t.Error("jti claim is empty")
+			}
+			exp, _ := claims["exp"].(float64)
+			iat, _ := claims["iat"].(float64)
+			if got, want := exp-iat, float64(60); got != want {
+				t.Errorf("exp-iat = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestJWTAssertionSourceLifetimeClamped(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	assertionFn, err := NewJWTAssertionSource(JWTAssertionSource{
+		Key: pemEncodePKCS8(t, rsaKey),
+		AssertionClaims: AssertionClaims{
+			Issuer:   "CLIENT_ID",
+			Subject:  "CLIENT_ID",
+			Audience: "https://example.com/token",
+			Lifetime: 24 * time.Hour,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAssertionSource: %v", err)
+	}
+	assertion, err := assertionFn(context.Background())
+	if err != nil {
+		t.Fatalf("assertionFn: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(assertion, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	claims := parsed.Claims.(jwt.MapClaims)
+	exp, _ := claims["exp"].(float64)
+	iat, _ := claims["iat"].(float64)
+	if got, want := exp-iat, float64(maxAssertionLifetime/time.Second); got != want {
+		t.Errorf("exp-iat = %v, want %v (clamped to max lifetime)", got, want)
+	}
+}
+
+func TestNewJWTAssertionSourceRequiresKeyOrSigner(t *testing.T) {
+	if _, err := NewJWTAssertionSource(JWTAssertionSource{}); err == nil {
+		t.Error("expected error when neither Key nor Signer is set")
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultMaxRegistryEntries is the default value of Registry.MaxEntries.
+const DefaultMaxRegistryEntries = 1024
+
+// Registry maintains one caching token source per distinct client
+// credentials configuration, shared across goroutines and request
+// handlers. It's intended for gateways/proxies that forward requests
+// to many upstream APIs, each authenticated with its own client
+// credentials, where building a fresh token source per request would
+// otherwise mean no caching at all.
+//
+// The zero value, once Ctx and MaxEntries are set, is ready to use; most
+// callers should use NewRegistry.
+type Registry struct {
+	// Ctx is used as the context for every token source's token
+	// requests.
+	Ctx context.Context
+
+	// MaxEntries bounds the number of distinct configurations kept in
+	// the registry; the least recently used entry is evicted once the
+	// bound is reached. Zero means DefaultMaxRegistryEntries.
+	MaxEntries int
+
+	// Options configures the caching token source created for each
+	// registry entry.
+	Options CachingTokenSourceOptions
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // key -> *registryEntry
+	order   *list.List               // front = most recently used
+}
+
+type registryEntry struct {
+	key string
+	ts  oauth2.TokenSource
+}
+
+// NewRegistry returns a Registry that authenticates token requests
+// using ctx and evicts least-recently-used entries once more than
+// maxEntries distinct configurations have been seen. maxEntries <= 0
+// means DefaultMaxRegistryEntries.
+func NewRegistry(ctx context.Context, maxEntries int, opts CachingTokenSourceOptions) *Registry {
+	return &Registry{Ctx: ctx, MaxEntries: maxEntries, Options: opts}
+}
+
+func (r *Registry) init() {
+	if r.entries == nil {
+		r.entries = make(map[string]*list.Element)
+		r.order = list.New()
+	}
+}
+
+func (r *Registry) maxEntries() int {
+	if r.MaxEntries <= 0 {
+		return DefaultMaxRegistryEntries
+	}
+	return r.MaxEntries
+}
+
+// Get returns a caching oauth2.TokenSource for cfg, creating one if no
+// equivalent configuration has been seen before. Configs are considered
+// equivalent if they canonicalize to the same key (see configKey): this
+// ignores field ordering in Scopes and EndpointParams, and, notably,
+// ClientSecret, ClientAssertionFn, and AuthStyle are not part of the
+// cache identity at all. So rotating a client's secret without calling
+// Evict first will silently keep handing out tokens fetched with the
+// old secret; use Evict to force a fresh token source once credentials
+// change.
+func (r *Registry) Get(cfg *Config) oauth2.TokenSource {
+	key := configKey(cfg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.init()
+
+	if el, ok := r.entries[key]; ok {
+		r.order.MoveToFront(el)
+		return el.Value.(*registryEntry).ts
+	}
+
+	ts := NewCachingTokenSource(r.Ctx, cfg, r.Options)
+	el := r.order.PushFront(&registryEntry{key: key, ts: ts})
+	r.entries[key] = el
+
+	if r.order.Len() > r.maxEntries() {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*registryEntry).key)
+	}
+	return ts
+}
+
+// Evict removes the entry for cfg, if any, so that a future Get builds
+// a fresh token source. Use this when a client's credentials have been
+// rotated out from under a still-running process.
+func (r *Registry) Evict(cfg *Config) {
+	key := configKey(cfg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.init()
+
+	if el, ok := r.entries[key]; ok {
+		r.order.Remove(el)
+		delete(r.entries, key)
+	}
+}
+
+// configKey canonicalizes cfg into a stable cache key, independent of
+// scope/param ordering.
+func configKey(cfg *Config) string {
+	scopes := append([]string(nil), cfg.Scopes...)
+	sort.Strings(scopes)
+
+	var params []string
+	for k, vs := range cfg.EndpointParams {
+		vs = append([]string(nil), vs...)
+		sort.Strings(vs)
+		params = append(params, k+"="+strings.Join(vs, ","))
+	}
+	sort.Strings(params)
+
+	return strings.Join([]string{
+		cfg.ClientID,
+		cfg.TokenURL,
+		strings.Join(scopes, " "),
+		strings.Join(params, "&"),
+	}, "\x00")
+}
+
+// Transport returns an http.RoundTripper that injects a bearer token
+// from Registry.Get(cfg) into every request's Authorization header
+// before delegating to base (http.DefaultTransport if nil). On a 401
+// response whose WWW-Authenticate header signals error="invalid_token",
+// the registry entry is evicted and the request is retried once with a
+// freshly fetched token; other 401s (e.g. insufficient_scope, or no
+// WWW-Authenticate at all) are returned unmodified, since forcing a
+// refresh wouldn't change the outcome.
+func (r *Registry) Transport(cfg *Config, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &registryTransport{registry: r, cfg: cfg, base: base}
+}
+
+type registryTransport struct {
+	registry *Registry
+	cfg      *Config
+	base     http.RoundTripper
+}
+
+func (t *registryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	getBody, err := requestBodyGetter(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/clientcredentials: buffering request body: %w", err)
+	}
+
+	resp, err := t.roundTripWithToken(req, getBody, t.registry.Get(t.cfg))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !invalidTokenChallenge(resp) {
+		return resp, err
+	}
+
+	t.registry.Evict(t.cfg)
+	resp.Body.Close()
+	return t.roundTripWithToken(req, getBody, t.registry.Get(t.cfg))
+}
+
+func (t *registryTransport) roundTripWithToken(req *http.Request, getBody func() (io.ReadCloser, error), ts oauth2.TokenSource) (*http.Response, error) {
+	tok, err := ts.Token()
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/clientcredentials: fetching token: %w", err)
+	}
+	r2 := req.Clone(req.Context())
+	if getBody != nil {
+		body, err := getBody()
+		if err != nil {
+			return nil, fmt.Errorf("oauth2/clientcredentials: rewinding request body: %w", err)
+		}
+		r2.Body = body
+	}
+	tok.SetAuthHeader(r2)
+	return t.base.RoundTrip(r2)
+}
+
+// requestBodyGetter returns a function producing a fresh, independent
+// copy of req's body on each call, so the body can be safely replayed
+// across the retry in RoundTrip. req.Clone shallow-copies Body (it's
+// just an io.ReadCloser), so without this the first attempt would
+// fully drain it before the retry ever reads from it.
+//
+// If req already has GetBody (true of requests built by http.NewRequest
+// with a recognized body type), that's reused directly. Otherwise — as
+// with an incoming server request's Body, which is exactly what a
+// reverse proxy gets — the body is read into memory once so it can be
+// replayed from there.
+func requestBodyGetter(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	buf, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}, nil
+}
+
+// invalidTokenChallenge reports whether resp carries a
+// WWW-Authenticate header indicating the bearer token itself was
+// rejected, as opposed to some other reason for a 401.
+func invalidTokenChallenge(resp *http.Response) bool {
+	for _, v := range resp.Header.Values("WWW-Authenticate") {
+		if strings.Contains(v, `error="invalid_token"`) {
+			return true
+		}
+	}
+	return false
+}
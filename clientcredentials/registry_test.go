@@ -0,0 +1,191 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRegistryGetReusesEquivalentConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=tok&token_type=bearer&expires_in=3600"))
+	}))
+	defer ts.Close()
+
+	reg := NewRegistry(context.Background(), 0, CachingTokenSourceOptions{})
+
+	cfg1 := &Config{ClientID: "a", ClientSecret: "s", TokenURL: ts.URL, Scopes: []string{"b", "a"}}
+	cfg2 := &Config{ClientID: "a", ClientSecret: "s", TokenURL: ts.URL, Scopes: []string{"a", "b"}}
+	cfg3 := &Config{ClientID: "other", ClientSecret: "s", TokenURL: ts.URL}
+
+	if reg.Get(cfg1) != reg.Get(cfg2) {
+		t.Error("expected equivalent configs (differing only in scope order) to share a token source")
+	}
+	if reg.Get(cfg1) == reg.Get(cfg3) {
+		t.Error("expected distinct client IDs to get distinct token sources")
+	}
+}
+
+func TestRegistryEvictForcesNewSource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=tok&token_type=bearer&expires_in=3600"))
+	}))
+	defer ts.Close()
+
+	reg := NewRegistry(context.Background(), 0, CachingTokenSourceOptions{})
+	cfg := &Config{ClientID: "a", ClientSecret: "s", TokenURL: ts.URL}
+
+	first := reg.Get(cfg)
+	reg.Evict(cfg)
+	second := reg.Get(cfg)
+	if first == second {
+		t.Error("expected Evict to force a new token source on next Get")
+	}
+}
+
+func TestRegistryMaxEntriesEvictsLRU(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=tok&token_type=bearer&expires_in=3600"))
+	}))
+	defer ts.Close()
+
+	reg := NewRegistry(context.Background(), 2, CachingTokenSourceOptions{})
+	cfgA := &Config{ClientID: "a", TokenURL: ts.URL}
+	cfgB := &Config{ClientID: "b", TokenURL: ts.URL}
+	cfgC := &Config{ClientID: "c", TokenURL: ts.URL}
+
+	srcA := reg.Get(cfgA)
+	reg.Get(cfgB)
+	reg.Get(cfgC) // evicts cfgA, the least recently used
+
+	if reg.Get(cfgA) == srcA {
+		t.Error("expected cfgA's entry to have been evicted once MaxEntries was exceeded")
+	}
+}
+
+func TestRegistryTransportInjectsBearerTokenAndRetriesOnInvalidToken(t *testing.T) {
+	tokenCalls := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls++
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=tok-" + strconv.Itoa(tokenCalls) + "&token_type=bearer&expires_in=3600"))
+	}))
+	defer tokenServer.Close()
+
+	var gotAuth []string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		if len(gotAuth) == 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apiServer.Close()
+
+	reg := NewRegistry(context.Background(), 0, CachingTokenSourceOptions{})
+	cfg := &Config{ClientID: "a", TokenURL: tokenServer.URL}
+	client := &http.Client{Transport: reg.Transport(cfg, nil)}
+
+	resp, err := client.Get(apiServer.URL)
+	if err != nil {
+		t.Fatalf("client.Get: %v", err)
+	}
+	defer resp.Body.Close()
+	drainBody(t, resp)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("final status = %d, want 200", resp.StatusCode)
+	}
+	if len(gotAuth) != 2 {
+		t.Fatalf("apiServer hit %d times, want 2 (initial + retry)", len(gotAuth))
+	}
+	if gotAuth[0] == gotAuth[1] {
+		t.Error("expected retry to carry a freshly fetched token after eviction")
+	}
+	if tokenCalls != 2 {
+		t.Errorf("token endpoint hit %d times, want 2 (cached, then re-fetched after eviction)", tokenCalls)
+	}
+}
+
+// TestRegistryTransportRetriesWithBodyNoGetBody covers the gateway use
+// case: a request whose Body is a plain io.ReadCloser with no GetBody,
+// exactly what a reverse proxy gets from an incoming *http.Request.
+// Without buffering, the first attempt drains the body and the retry
+// fails with a ContentLength mismatch.
+func TestRegistryTransportRetriesWithBodyNoGetBody(t *testing.T) {
+	tokenCalls := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls++
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=tok-" + strconv.Itoa(tokenCalls) + "&token_type=bearer&expires_in=3600"))
+	}))
+	defer tokenServer.Close()
+
+	var gotBodies []string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %v", err)
+		}
+		gotBodies = append(gotBodies, string(body))
+		if len(gotBodies) == 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apiServer.Close()
+
+	reg := NewRegistry(context.Background(), 0, CachingTokenSourceOptions{})
+	cfg := &Config{ClientID: "a", TokenURL: tokenServer.URL}
+	transport := reg.Transport(cfg, apiServer.Client().Transport)
+
+	const payload = "hello world"
+	req, err := http.NewRequest(http.MethodPost, apiServer.URL, strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("http.NewRequest: %v", err)
+	}
+	req.ContentLength = int64(len(payload))
+	req.GetBody = nil
+	req.Body = ioutil.NopCloser(strings.NewReader(payload))
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	defer resp.Body.Close()
+	drainBody(t, resp)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("final status = %d, want 200", resp.StatusCode)
+	}
+	if len(gotBodies) != 2 {
+		t.Fatalf("apiServer hit %d times, want 2 (initial + retry)", len(gotBodies))
+	}
+	for i, got := range gotBodies {
+		if got != payload {
+			t.Errorf("attempt %d body = %q, want %q", i, got, payload)
+		}
+	}
+}
+
+func drainBody(t *testing.T, resp *http.Response) {
+	t.Helper()
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatalf("reading response body: %v", err)
+	}
+}
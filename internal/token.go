@@ -0,0 +1,174 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package internal provides the HTTP plumbing shared by the OAuth2
+// client grant flows implemented in this module (clientcredentials,
+// tokenexchange). It is not part of the public API.
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// AuthConfig carries the client-authentication fields shared by every
+// grant type implemented in this module.
+type AuthConfig struct {
+	ClientID     string
+	ClientSecret string
+
+	// ClientAssertionFn, when set, is used in place of ClientSecret to
+	// authenticate the client (e.g. RFC 7523 JWT bearer assertions).
+	ClientAssertionFn func(ctx context.Context) (string, error)
+
+	// IncludeClientSecretWithAssertion sends ClientSecret alongside
+	// ClientAssertionFn's assertion instead of omitting it. Most
+	// providers reject this combination; a few (e.g. Keycloak) require it.
+	IncludeClientSecretWithAssertion bool
+
+	TokenURL  string
+	AuthStyle oauth2.AuthStyle
+}
+
+var knownTokenFields = map[string]bool{
+	"access_token":  true,
+	"token_type":    true,
+	"refresh_token": true,
+	"expires_in":    true,
+}
+
+// RetrieveToken POSTs v to c.TokenURL, authenticating the client per
+// c.AuthStyle (or via ClientAssertionFn when set), and decodes the
+// resulting token. Any response fields besides the well-known OAuth2
+// ones are preserved on the returned Token and reachable via
+// (*oauth2.Token).Extra.
+func RetrieveToken(ctx context.Context, c AuthConfig, v url.Values) (*oauth2.Token, error) {
+	// client_id identifies the client regardless of how it authenticates,
+	// so it is always sent: in the Basic auth header when the secret goes
+	// there, otherwise in the request body.
+	sendSecret := c.ClientAssertionFn == nil || c.IncludeClientSecretWithAssertion
+
+	if c.ClientAssertionFn != nil {
+		assertion, err := c.ClientAssertionFn(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("oauth2: generating client assertion: %w", err)
+		}
+		v.Set("client_assertion", assertion)
+		v.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	}
+
+	useHeaderAuth := sendSecret && c.AuthStyle == oauth2.AuthStyleInHeader
+	if !useHeaderAuth {
+		if c.ClientID != "" {
+			v.Set("client_id", c.ClientID)
+		}
+		if sendSecret && c.ClientSecret != "" {
+			v.Set("client_secret", c.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.TokenURL, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if useHeaderAuth {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	r, err := contextClient(ctx).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot fetch token: %v", err)
+	}
+	if code := r.StatusCode; code < 200 || code > 299 {
+		return nil, &oauth2.RetrieveError{
+			Response: r,
+			Body:     body,
+		}
+	}
+
+	token := &oauth2.Token{}
+	extra := map[string]interface{}{}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case "application/x-www-form-urlencoded", "text/plain":
+		vals, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		token.AccessToken = vals.Get("access_token")
+		token.TokenType = vals.Get("token_type")
+		token.RefreshToken = vals.Get("refresh_token")
+		if e := vals.Get("expires_in"); e != "" {
+			if expires, err := strconv.Atoi(e); err == nil && expires != 0 {
+				token.Expiry = time.Now().Add(time.Duration(expires) * time.Second)
+			}
+		}
+		for k, vs := range vals {
+			if !knownTokenFields[k] && len(vs) > 0 {
+				extra[k] = vs[0]
+			}
+		}
+	default:
+		var raw map[string]interface{}
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, err
+		}
+		if s, ok := raw["access_token"].(string); ok {
+			token.AccessToken = s
+		}
+		if s, ok := raw["token_type"].(string); ok {
+			token.TokenType = s
+		}
+		if s, ok := raw["refresh_token"].(string); ok {
+			token.RefreshToken = s
+		}
+		if e, ok := raw["expires_in"]; ok {
+			switch n := e.(type) {
+			case float64:
+				if n != 0 {
+					token.Expiry = time.Now().Add(time.Duration(n) * time.Second)
+				}
+			case string:
+				if secs, err := strconv.ParseInt(n, 10, 64); err == nil && secs != 0 {
+					token.Expiry = time.Now().Add(time.Duration(secs) * time.Second)
+				}
+			}
+		}
+		for k, v := range raw {
+			if !knownTokenFields[k] {
+				extra[k] = v
+			}
+		}
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2: server response missing access_token")
+	}
+	if len(extra) > 0 {
+		token = token.WithExtra(extra)
+	}
+	return token, nil
+}
+
+func contextClient(ctx context.Context) *http.Client {
+	if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		return c
+	}
+	return http.DefaultClient
+}
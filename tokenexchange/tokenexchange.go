@@ -0,0 +1,194 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+// Package tokenexchange implements the OAuth 2.0 Token Exchange grant
+// defined in RFC 8693, used to trade a "subject" token (and optionally
+// an "actor" token) for a new access token, e.g. to federate a
+// short-lived workload identity token (a Kubernetes projected service
+// account token, a GitHub Actions OIDC token, an AWS STS token) into an
+// OAuth2 access token for a downstream API.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8693
+package tokenexchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+
+	"github.com/polytomic/oauth2/internal"
+)
+
+// grantType is the RFC 8693 §2.1 grant_type value for token exchange.
+const grantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+// TokenSupplier returns the token and token type to present in a token
+// exchange request. It is called on every refresh so short-lived
+// workload identity tokens can be re-read from their source (a
+// projected file, an OIDC endpoint, an STS credential) instead of
+// going stale.
+type TokenSupplier func(ctx context.Context) (token, tokenType string, err error)
+
+// staticTokenSupplier returns a TokenSupplier for a fixed token and
+// token type, used when callers set SubjectToken/SubjectTokenType (or
+// ActorToken/ActorTokenType) directly instead of providing a supplier.
+func staticTokenSupplier(token, tokenType string) TokenSupplier {
+	return func(context.Context) (string, string, error) {
+		return token, tokenType, nil
+	}
+}
+
+// Config describes an RFC 8693 token exchange flow, with both the
+// client application information and the server's endpoint URL.
+type Config struct {
+	// ClientID is the application's ID.
+	ClientID string
+
+	// ClientSecret is the application's secret.
+	ClientSecret string
+
+	// ClientAssertionFn allows the client to generate a fresh client
+	// assertion (e.g. an RFC 7523 JWT bearer assertion) on each token
+	// request, in place of ClientSecret.
+	ClientAssertionFn func(ctx context.Context) (string, error)
+
+	// TokenURL is the resource server's token endpoint URL.
+	TokenURL string
+
+	// AuthStyle optionally specifies how the endpoint wants the
+	// client ID & client secret sent. The zero value means to
+	// authenticate via the request body (AuthStyleInParams).
+	AuthStyle oauth2.AuthStyle
+
+	// SubjectToken and SubjectTokenType are used when
+	// SubjectTokenSupplier is nil. One of SubjectToken or
+	// SubjectTokenSupplier must be set.
+	SubjectToken     string
+	SubjectTokenType string
+
+	// SubjectTokenSupplier, when set, is called on every refresh to
+	// obtain the subject token and its type, taking precedence over
+	// SubjectToken/SubjectTokenType.
+	SubjectTokenSupplier TokenSupplier
+
+	// ActorToken and ActorTokenType are used when ActorTokenSupplier
+	// is nil. Actor tokens are optional.
+	ActorToken     string
+	ActorTokenType string
+
+	// ActorTokenSupplier, when set, is called on every refresh to
+	// obtain the actor token and its type, taking precedence over
+	// ActorToken/ActorTokenType.
+	ActorTokenSupplier TokenSupplier
+
+	// Resource identifies the target service or resource where the
+	// client intends to use the requested token, per RFC 8693 §2.1.
+	Resource string
+
+	// Audience identifies the target service or resource, as a
+	// logical name rather than a URI, per RFC 8693 §2.1.
+	Audience string
+
+	// Scope specifies optional requested permissions.
+	Scope []string
+
+	// RequestedTokenType is the type of token being requested, per
+	// RFC 8693 §3. If empty, the server's default applies (typically
+	// an access token).
+	RequestedTokenType string
+}
+
+// Token performs the token exchange.
+func (c *Config) Token(ctx context.Context) (*oauth2.Token, error) {
+	return c.TokenSource(ctx).Token()
+}
+
+// Client returns an HTTP client whose requests are authorized with an
+// exchanged token, refreshed automatically as necessary.
+func (c *Config) Client(ctx context.Context) *http.Client {
+	return oauth2.NewClient(ctx, c.TokenSource(ctx))
+}
+
+// TokenSource returns a TokenSource that performs the token exchange,
+// automatically refreshing as necessary. Each refresh re-reads the
+// subject (and actor) token via SubjectTokenSupplier/ActorTokenSupplier,
+// so rotated workload identity tokens are picked up without restarting
+// the process.
+func (c *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &tokenSource{ctx: ctx, conf: c})
+}
+
+type tokenSource struct {
+	ctx  context.Context
+	conf *Config
+}
+
+func (c *tokenSource) Token() (*oauth2.Token, error) {
+	subjectSupplier := c.conf.SubjectTokenSupplier
+	if subjectSupplier == nil {
+		if c.conf.SubjectToken == "" {
+			return nil, fmt.Errorf("oauth2/tokenexchange: one of SubjectToken or SubjectTokenSupplier is required")
+		}
+		subjectSupplier = staticTokenSupplier(c.conf.SubjectToken, c.conf.SubjectTokenType)
+	}
+	subjectToken, subjectTokenType, err := subjectSupplier(c.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/tokenexchange: reading subject token: %w", err)
+	}
+
+	v := url.Values{
+		"grant_type":         {grantType},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {subjectTokenType},
+	}
+
+	actorSupplier := c.conf.ActorTokenSupplier
+	if actorSupplier == nil && c.conf.ActorToken != "" {
+		actorSupplier = staticTokenSupplier(c.conf.ActorToken, c.conf.ActorTokenType)
+	}
+	if actorSupplier != nil {
+		actorToken, actorTokenType, err := actorSupplier(c.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("oauth2/tokenexchange: reading actor token: %w", err)
+		}
+		v.Set("actor_token", actorToken)
+		v.Set("actor_token_type", actorTokenType)
+	}
+
+	if c.conf.Resource != "" {
+		v.Set("resource", c.conf.Resource)
+	}
+	if c.conf.Audience != "" {
+		v.Set("audience", c.conf.Audience)
+	}
+	if len(c.conf.Scope) > 0 {
+		v.Set("scope", strings.Join(c.conf.Scope, " "))
+	}
+	if c.conf.RequestedTokenType != "" {
+		v.Set("requested_token_type", c.conf.RequestedTokenType)
+	}
+
+	return internal.RetrieveToken(c.ctx, internal.AuthConfig{
+		ClientID:          c.conf.ClientID,
+		ClientSecret:      c.conf.ClientSecret,
+		ClientAssertionFn: c.conf.ClientAssertionFn,
+		TokenURL:          c.conf.TokenURL,
+		AuthStyle:         c.conf.AuthStyle,
+	}, v)
+}
+
+// IssuedTokenType returns the RFC 8693 §2.2.1 "issued_token_type" field
+// from the token exchange response, e.g.
+// "urn:ietf:params:oauth:token-type:access_token". It returns "" if the
+// server didn't include one.
+func IssuedTokenType(tok *oauth2.Token) string {
+	if s, ok := tok.Extra("issued_token_type").(string); ok {
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Polytomic, Inc.
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package tokenexchange
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func assert(t *testing.T, want, got string) {
+	t.Helper()
+	if got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestTokenExchange(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/token" {
+			t.Errorf("authenticate client request URL = %q; want %q", r.URL, "/token")
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			t.Fatalf("ParseQuery: %v", err)
+		}
+
+		assert(t, grantType, values.Get("grant_type"))
+		assert(t, "SUBJECT_TOKEN", values.Get("subject_token"))
+		assert(t, "urn:ietf:params:oauth:token-type:id_token", values.Get("subject_token_type"))
+		assert(t, "ACTOR_TOKEN", values.Get("actor_token"))
+		assert(t, "urn:ietf:params:oauth:token-type:access_token", values.Get("actor_token_type"))
+		assert(t, "https://api.example.com/", values.Get("resource"))
+		assert(t, "urn:example:audience", values.Get("audience"))
+		assert(t, "read write", values.Get("scope"))
+		assert(t, "urn:ietf:params:oauth:token-type:access_token", values.Get("requested_token_type"))
+		assert(t, "CLIENT_ID", values.Get("client_id"))
+		assert(t, "CLIENT_SECRET", values.Get("client_secret"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"access_token": "EXCHANGED_TOKEN",
+			"token_type": "Bearer",
+			"issued_token_type": "urn:ietf:params:oauth:token-type:access_token",
+			"expires_in": 3600
+		}`))
+	}))
+	defer ts.Close()
+
+	conf := &Config{
+		ClientID:           "CLIENT_ID",
+		ClientSecret:       "CLIENT_SECRET",
+		TokenURL:           ts.URL + "/token",
+		SubjectToken:       "SUBJECT_TOKEN",
+		SubjectTokenType:   "urn:ietf:params:oauth:token-type:id_token",
+		ActorToken:         "ACTOR_TOKEN",
+		ActorTokenType:     "urn:ietf:params:oauth:token-type:access_token",
+		Resource:           "https://api.example.com/",
+		Audience:           "urn:example:audience",
+		Scope:              []string{"read", "write"},
+		RequestedTokenType: "urn:ietf:params:oauth:token-type:access_token",
+	}
+
+	tok, err := conf.Token(context.Background())
+	if err != nil {
+		t.Fatalf("conf.Token: %v", err)
+	}
+	if tok.AccessToken != "EXCHANGED_TOKEN" {
+		t.Errorf("AccessToken = %q, want EXCHANGED_TOKEN", tok.AccessToken)
+	}
+	if got := IssuedTokenType(tok); got != "urn:ietf:params:oauth:token-type:access_token" {
+		t.Errorf("IssuedTokenType = %q, want access_token type", got)
+	}
+}
+
+func TestTokenExchangeSubjectTokenSupplierRereadOnRefresh(t *testing.T) {
+	var call int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		values, _ := url.ParseQuery(string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "` + values.Get("subject_token") + `-exchanged", "token_type": "Bearer"}`))
+	}))
+	defer ts.Close()
+
+	conf := &Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL + "/token",
+		SubjectTokenSupplier: func(ctx context.Context) (string, string, error) {
+			n := atomic.AddInt32(&call, 1)
+			return "rotated-token-" + string(rune('0'+n)), "urn:ietf:params:oauth:token-type:jwt", nil
+		},
+	}
+
+	src := conf.TokenSource(context.Background())
+	// Force two independent fetches, simulating successive refreshes;
+	// ReuseTokenSource would cache the first, so call the underlying
+	// tokenSource directly to exercise supplier re-invocation.
+	inner := &tokenSource{ctx: context.Background(), conf: conf}
+	first, err := inner.Token()
+	if err != nil {
+		t.Fatalf("first Token: %v", err)
+	}
+	second, err := inner.Token()
+	if err != nil {
+		t.Fatalf("second Token: %v", err)
+	}
+	if first.AccessToken == second.AccessToken {
+		t.Errorf("expected supplier to be re-read on each call, got same token %q twice", first.AccessToken)
+	}
+	if call != 2 {
+		t.Errorf("SubjectTokenSupplier called %d times, want 2", call)
+	}
+	_ = src
+}